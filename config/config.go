@@ -1,29 +1,103 @@
 package config
 
+import (
+	"github.com/iokiris/glockmon/eventlog"
+	"github.com/iokiris/glockmon/metrics"
+	"time"
+)
+
+// DeadlockPolicy controls what the Monitor does when it promotes a long
+// lock into a suspected deadlock.
+type DeadlockPolicy int
+
+const (
+	// DeadlockPolicyReport only records the deadlock for inspection via the
+	// /deadlocks endpoint and OnDeadlock callbacks.
+	DeadlockPolicyReport DeadlockPolicy = iota
+	// DeadlockPolicyLog additionally logs the deadlock using the standard
+	// log package.
+	DeadlockPolicyLog
+	// DeadlockPolicyCrash panics, as a last resort, so a supervisor can
+	// restart the process.
+	DeadlockPolicyCrash
+)
+
 // HTTPConfig holds the URL paths for the HTTP monitoring endpoints.
 //
 // Blocked:    Endpoint to get the list of long locks (e.g. "/blocked").
+//
+//	Accepts an optional "type=read|write" query filter.
+//
 // Categories: Endpoint to get statistics grouped by categories (e.g. "/categories").
 // Stack:      Endpoint prefix to get the stack trace by its ID (e.g. "/stacks/").
 //
 //	Note the trailing slash, since the stack ID is appended to this path.
+//
+// Holders:    Endpoint prefix to get the current holders of a MonitoredRWMutex
+//
+//	by its lock ID (e.g. "/holders/"). Note the trailing slash, since the
+//	lock ID is appended to this path.
+//
+// Deadlocks:  Endpoint to get the list of suspected deadlocks (e.g. "/deadlocks").
+// Metrics:    Endpoint serving the Prometheus scrape format (e.g. "/metrics").
+//
+//	Empty disables the endpoint. Serves the registry of a
+//	metrics.PrometheusSink found among MonitorConfig.Sinks, if any;
+//	falls back to prometheus.DefaultRegisterer otherwise.
+//
+// Events:     Endpoint streaming persisted event log entries as
+//
+//	newline-delimited JSON (e.g. "/events"). Accepts optional
+//	"since=<unixnano>", "category=<cat>" and "limit=N" query filters.
+//	Returns 404 if no EventLog is configured.
 type HTTPConfig struct {
 	Blocked    string
 	Categories string
 	Stack      string
+	Holders    string
+	Deadlocks  string
+	Metrics    string
+	Events     string
 }
 
 // MonitorConfig contains configuration settings for the lock monitor and its HTTP server.
 //
-// KeepRecords:     Whether to keep detailed lock records in memory after unlocking.
-// DefaultCategory: The default category name assigned to locks if none is set.
-// HTTPServerAddr:  Address and port where the HTTP server will listen (e.g. ":8080").
-// HTTPEndpoints:   Struct holding URL paths for the HTTP monitoring endpoints.
+// KeepRecords:         Whether to keep detailed lock records in memory after unlocking.
+// DefaultCategory:     The default category name assigned to locks if none is set.
+// HTTPServerAddr:      Address and port where the HTTP server will listen (e.g. ":8080").
+// HTTPEndpoints:       Struct holding URL paths for the HTTP monitoring endpoints.
+// DeadlockThreshold:   How long a MonitoredMutex.Lock call must block before its
+//
+//	watchdog reports a suspected deadlock. Zero disables deadlock detection.
+//
+// DeadlockPolicy:      What to do once a deadlock is detected (Report, Log or Crash).
+// Sinks:               Push-based metrics sinks that every recorded lock event and
+//
+//	deadlock fans out to, e.g. a metrics.StatsDSink or metrics.PrometheusSink.
+//
+// MaxTrackedStacks: Upper bound on the number of distinct call sites kept in
+//
+//	memory at once; the least-recently-seen call site is evicted past this
+//	cap. Defaults to 10_000 if unset.
+//
+// StaleWindow: How old a call site's last event must be for it to match the
+//
+//	/blocked?stale=true filter. Defaults to 5m if unset.
+//
+// EventLog: Optional on-disk rolling log of every recorded lock event,
+//
+//	queryable via the Events endpoint. Zero value (empty Path) disables it.
 type MonitorConfig struct {
-	KeepRecords     bool
-	DefaultCategory string
-	HTTPServerAddr  string
-	HTTPEndpoints   HTTPConfig
+	KeepRecords       bool
+	DefaultCategory   string
+	HTTPServerAddr    string
+	HTTPEndpoints     HTTPConfig
+	DeadlockThreshold time.Duration
+	DeadlockPolicy    DeadlockPolicy
+	Sinks             []metrics.Sink
+	MaxTrackedStacks  int
+	StaleWindow       time.Duration
+	EventLog          eventlog.Config
 }
 
 // Default returns a MonitorConfig instance with sane default values.
@@ -36,6 +110,16 @@ func Default() *MonitorConfig {
 			Blocked:    "/blocked",
 			Categories: "/categories",
 			Stack:      "/stacks/",
+			Holders:    "/holders/",
+			Deadlocks:  "/deadlocks",
+			Metrics:    "",
+			Events:     "/events",
 		},
+		DeadlockThreshold: 0,
+		DeadlockPolicy:    DeadlockPolicyReport,
+		Sinks:             nil,
+		MaxTrackedStacks:  10_000,
+		StaleWindow:       5 * time.Minute,
+		EventLog:          eventlog.Config{},
 	}
 }
@@ -0,0 +1,240 @@
+// Package eventlog implements an on-disk, rotating JSON-lines log of lock
+// events, so operators can inspect lock history after the fact without
+// keeping every event in memory.
+package eventlog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is a single long-lock event as persisted to the event log.
+//
+// Timestamp: when the lock was taken.
+// Wait: how long it took to get the lock.
+// Category: the category label the event was recorded under.
+// Type: LockTypeRead or LockTypeWrite.
+// Stack: the call stack as a string.
+type Entry struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Wait      time.Duration `json:"wait_ns"`
+	Category  string        `json:"category"`
+	Type      string        `json:"type"`
+	Stack     string        `json:"stack"`
+}
+
+// Config controls where and how the event log is written.
+//
+// Path: file path of the active log (e.g. "glockmon-events.jsonl"). Empty
+//
+//	disables the event log entirely.
+//
+// MaxSizeMB: size, in megabytes, at which the active log is rotated. Zero
+//
+//	disables rotation.
+//
+// MaxBackups: number of rotated files to keep; older ones are deleted.
+//
+//	Zero keeps all of them.
+//
+// Compress: whether rotated files are gzip-compressed.
+type Config struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	Compress   bool
+}
+
+// queueSize bounds how many entries can be buffered between Write and the
+// background flusher before new entries are dropped.
+const queueSize = 1024
+
+// Writer appends Entry values to a rotating JSON-lines file in the
+// background, so callers never block on disk I/O.
+//
+// Use NewWriter to create one, Write to enqueue entries, and Close to flush
+// and release the underlying file.
+type Writer struct {
+	cfg   Config
+	queue chan Entry
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	seq  int // highest path.N backup seen so far; rotate uses seq+1 next
+}
+
+// NewWriter opens (or creates) cfg.Path and starts a background flusher
+// that batches writes so the caller (typically Monitor.Add) never blocks on
+// disk I/O. It also scans for existing path.N backups and resumes numbering
+// after the highest one found, so restarting the process doesn't clobber
+// backups from a previous run.
+func NewWriter(cfg Config) (*Writer, error) {
+	f, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("eventlog: open %s: %w", cfg.Path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("eventlog: stat %s: %w", cfg.Path, err)
+	}
+
+	seq := 0
+	if backups := backupPaths(cfg.Path); len(backups) > 0 {
+		seq = backupSeq(backups[len(backups)-1], cfg.Path)
+	}
+
+	w := &Writer{
+		cfg:   cfg,
+		queue: make(chan Entry, queueSize),
+		done:  make(chan struct{}),
+		file:  f,
+		size:  info.Size(),
+		seq:   seq,
+	}
+
+	w.wg.Add(1)
+	go w.flush()
+	return w, nil
+}
+
+// Write enqueues e for writing. If the background flusher can't keep up and
+// the queue is full, e is dropped and logged rather than blocking the
+// caller.
+func (w *Writer) Write(e Entry) {
+	select {
+	case w.queue <- e:
+	default:
+		log.Printf("glockmon/eventlog: queue full, dropping event")
+	}
+}
+
+// Close stops the background flusher, draining any queued entries, and
+// closes the underlying file.
+func (w *Writer) Close() error {
+	close(w.done)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// flush drains the queue onto disk until Close is called, then makes one
+// final pass to flush anything queued in the meantime.
+func (w *Writer) flush() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case e := <-w.queue:
+			w.append(e)
+		case <-w.done:
+			for {
+				select {
+				case e := <-w.queue:
+					w.append(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Writer) append(e Entry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("glockmon/eventlog: marshal event: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.file.Write(line); err != nil {
+		log.Printf("glockmon/eventlog: write: %v", err)
+		return
+	}
+	w.size += int64(len(line))
+
+	if w.cfg.MaxSizeMB > 0 && w.size >= int64(w.cfg.MaxSizeMB)*1024*1024 {
+		if err := w.rotate(); err != nil {
+			log.Printf("glockmon/eventlog: rotate: %v", err)
+		}
+	}
+}
+
+// rotate closes the active file, renames it to the next path.N backup slot
+// (compressing it if cfg.Compress), prunes backups beyond cfg.MaxBackups,
+// and opens a fresh active file. Callers must hold w.mu.
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	w.seq++
+	backup := fmt.Sprintf("%s.%d", w.cfg.Path, w.seq)
+	if err := os.Rename(w.cfg.Path, backup); err != nil {
+		return err
+	}
+
+	if w.cfg.Compress {
+		if err := gzipFile(backup); err != nil {
+			log.Printf("glockmon/eventlog: compress %s: %v", backup, err)
+		}
+	}
+
+	if w.cfg.MaxBackups > 0 && w.seq > w.cfg.MaxBackups {
+		oldest := fmt.Sprintf("%s.%d", w.cfg.Path, w.seq-w.cfg.MaxBackups)
+		_ = os.Remove(oldest)
+		_ = os.Remove(oldest + ".gz")
+	}
+
+	f, err := os.OpenFile(w.cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.size = 0
+	return nil
+}
+
+// gzipFile compresses path in place, writing path+".gz" and removing path.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		_ = gw.Close()
+		_ = dst.Close()
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,108 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNewWriterResumesSeqFromBackups covers the restart-safety fix: NewWriter
+// must initialize seq from the highest existing path.N backup rather than
+// always starting at 0, or the first rotation after a restart clobbers a
+// pre-existing backup.
+func TestNewWriterResumesSeqFromBackups(t *testing.T) {
+	tests := []struct {
+		name        string
+		preexisting []string // backup suffixes to create before NewWriter, e.g. ".1", ".2"
+		wantSeq     int
+	}{
+		{name: "no backups", preexisting: nil, wantSeq: 0},
+		{name: "single backup", preexisting: []string{".1"}, wantSeq: 1},
+		{name: "multiple backups out of glob order", preexisting: []string{".2", ".1", ".10"}, wantSeq: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "events.jsonl")
+
+			for _, suffix := range tt.preexisting {
+				if err := os.WriteFile(path+suffix, []byte("{}\n"), 0644); err != nil {
+					t.Fatalf("seed backup %s: %v", suffix, err)
+				}
+			}
+
+			w, err := NewWriter(Config{Path: path})
+			if err != nil {
+				t.Fatalf("NewWriter: %v", err)
+			}
+			defer w.Close()
+
+			if w.seq != tt.wantSeq {
+				t.Fatalf("seq = %d, want %d", w.seq, tt.wantSeq)
+			}
+		})
+	}
+}
+
+// TestWriterRotateResumedNumberingDoesNotClobber exercises a full
+// restart-then-rotate cycle: a Writer rotates once, is closed and reopened
+// (simulating a process restart), then rotates again. The second rotation
+// must not overwrite the backup left by the first.
+func TestWriterRotateResumedNumberingDoesNotClobber(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+
+	w1, err := NewWriter(Config{Path: path})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	w1.mu.Lock()
+	err = w1.rotate()
+	w1.mu.Unlock()
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if err := w1.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	firstBackup := path + ".1"
+	if _, err := os.Stat(firstBackup); err != nil {
+		t.Fatalf("expected %s to exist: %v", firstBackup, err)
+	}
+	before, err := os.ReadFile(firstBackup)
+	if err != nil {
+		t.Fatalf("read %s: %v", firstBackup, err)
+	}
+
+	w2, err := NewWriter(Config{Path: path})
+	if err != nil {
+		t.Fatalf("NewWriter (restart): %v", err)
+	}
+	if w2.seq != 1 {
+		t.Fatalf("seq after restart = %d, want 1", w2.seq)
+	}
+
+	w2.mu.Lock()
+	err = w2.rotate()
+	w2.mu.Unlock()
+	if err != nil {
+		t.Fatalf("rotate after restart: %v", err)
+	}
+	defer w2.Close()
+
+	after, err := os.ReadFile(firstBackup)
+	if err != nil {
+		t.Fatalf("read %s after second rotate: %v", firstBackup, err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("%s was clobbered by the post-restart rotation", firstBackup)
+	}
+
+	secondBackup := path + ".2"
+	if _, err := os.Stat(secondBackup); err != nil {
+		t.Fatalf("expected %s to exist: %v", secondBackup, err)
+	}
+}
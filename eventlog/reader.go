@@ -0,0 +1,113 @@
+package eventlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stream writes every Entry from the rotated and active logs at cfg.Path
+// whose Timestamp is strictly after since (or all, if since is zero) to
+// out as newline-delimited JSON, optionally filtered to a single category.
+// Oldest backups are read first and the active log last, so output is
+// roughly chronological. Stops once limit entries have been written;
+// limit <= 0 means no limit.
+func Stream(cfg Config, out io.Writer, since time.Time, category string, limit int) error {
+	if cfg.Path == "" {
+		return fmt.Errorf("eventlog: no path configured")
+	}
+
+	paths := append(backupPaths(cfg.Path), cfg.Path)
+
+	written := 0
+	for _, p := range paths {
+		if limit > 0 && written >= limit {
+			break
+		}
+		n, err := streamFile(p, out, since, category, limit-written)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		written += n
+	}
+	return nil
+}
+
+// backupPaths returns the rotated backups for base, sorted oldest first.
+func backupPaths(base string) []string {
+	matches, _ := filepath.Glob(base + ".*")
+	sort.Slice(matches, func(i, j int) bool {
+		return backupSeq(matches[i], base) < backupSeq(matches[j], base)
+	})
+	return matches
+}
+
+// backupSeq parses the sequence number out of a "base.N" or "base.N.gz"
+// backup path, returning 0 if it doesn't match that pattern.
+func backupSeq(path, base string) int {
+	suffix := strings.TrimPrefix(path, base+".")
+	suffix = strings.TrimSuffix(suffix, ".gz")
+	n, _ := strconv.Atoi(suffix)
+	return n
+}
+
+// streamFile writes matching entries from a single log file (plain or
+// gzip-compressed) to out, returning how many were written.
+func streamFile(path string, out io.Writer, since time.Time, category string, limit int) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	written := 0
+	for scanner.Scan() {
+		if limit > 0 && written >= limit {
+			break
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !since.IsZero() && !e.Timestamp.After(since) {
+			continue
+		}
+		if category != "" && e.Category != category {
+			continue
+		}
+
+		if _, err := out.Write(scanner.Bytes()); err != nil {
+			return written, err
+		}
+		if _, err := out.Write([]byte("\n")); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, scanner.Err()
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"strings"
+	"time"
+)
+
+// PrometheusSink exposes lock events as a wait-duration Histogram and an
+// events Counter, both labeled by category and lock type, plus a deadlocks
+// Counter labeled by category.
+//
+// It registers its collectors on a caller-supplied *prometheus.Registry
+// rather than prometheus.DefaultRegisterer, so HTTPEndpoints.Metrics scrapes
+// this sink's own registry (via Gatherer) instead of assuming the default
+// one.
+//
+// Use NewPrometheusSink to create an instance.
+type PrometheusSink struct {
+	registry  *prometheus.Registry
+	wait      *prometheus.HistogramVec
+	events    *prometheus.CounterVec
+	deadlocks *prometheus.CounterVec
+}
+
+// NewPrometheusSink creates a PrometheusSink and registers its collectors
+// on reg.
+func NewPrometheusSink(reg *prometheus.Registry) *PrometheusSink {
+	s := &PrometheusSink{
+		registry: reg,
+		wait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "glockmon",
+			Subsystem: "lock",
+			Name:      "wait_seconds",
+			Help:      "Lock wait duration in seconds before a long lock was recorded.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"category", "type"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "glockmon",
+			Subsystem: "lock",
+			Name:      "events_total",
+			Help:      "Total number of long lock events recorded.",
+		}, []string{"category", "type"}),
+		deadlocks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "glockmon",
+			Subsystem: "lock",
+			Name:      "deadlocks_total",
+			Help:      "Total number of suspected deadlocks recorded.",
+		}, []string{"category"}),
+	}
+
+	reg.MustRegister(s.wait, s.events, s.deadlocks)
+	return s
+}
+
+// Gatherer returns the registry this sink's collectors were registered on,
+// so the HTTP server can scrape exactly this sink's metrics. See
+// HTTPServer.Start.
+func (s *PrometheusSink) Gatherer() prometheus.Gatherer {
+	return s.registry
+}
+
+// ObserveLockWait records d against the wait histogram and increments the
+// events counter for category and waitType.
+func (s *PrometheusSink) ObserveLockWait(category, waitType string, d time.Duration) {
+	labels := prometheus.Labels{"category": category, "type": strings.ToLower(waitType)}
+	s.wait.With(labels).Observe(d.Seconds())
+	s.events.With(labels).Inc()
+}
+
+// IncDeadlock increments the deadlocks counter for category.
+func (s *PrometheusSink) IncDeadlock(category string) {
+	s.deadlocks.With(prometheus.Labels{"category": category}).Inc()
+}
@@ -0,0 +1,18 @@
+// Package metrics lets a Monitor fan out lock events to push-based
+// observability backends, in addition to the pull-style HTTP API glockmon
+// already exposes.
+package metrics
+
+import "time"
+
+// Sink receives lock events recorded by a Monitor. Implementations must be
+// safe for concurrent use, since Monitor.Add may be called from many
+// goroutines at once.
+type Sink interface {
+	// ObserveLockWait reports that a lock of waitType ("READ" or "WRITE")
+	// in category was acquired after waiting d.
+	ObserveLockWait(category, waitType string, d time.Duration)
+
+	// IncDeadlock reports a suspected deadlock in category.
+	IncDeadlock(category string)
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// StatsDSink sends lock events to a StatsD/DogStatsD server over UDP,
+// reporting wait durations as a timer and event counts as a counter, both
+// tagged by category and lock type.
+//
+// Use NewStatsDSink to create an instance.
+type StatsDSink struct {
+	conn      net.Conn
+	namespace string
+}
+
+// NewStatsDSink dials addr (host:port) over UDP and returns a Sink that
+// sends metrics to it under the given namespace, e.g. "glockmon".
+func NewStatsDSink(addr, namespace string) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: dial statsd: %w", err)
+	}
+
+	return &StatsDSink{
+		conn:      conn,
+		namespace: namespace,
+	}, nil
+}
+
+// ObserveLockWait sends <namespace>.lock.wait_ms as a timer and
+// <namespace>.lock.count as a counter, both tagged by category and type.
+func (s *StatsDSink) ObserveLockWait(category, waitType string, d time.Duration) {
+	tags := fmt.Sprintf("#category:%s,type:%s", category, strings.ToLower(waitType))
+	s.send(fmt.Sprintf("%s.lock.wait_ms:%d|ms|%s", s.namespace, d.Milliseconds(), tags))
+	s.send(fmt.Sprintf("%s.lock.count:1|c|%s", s.namespace, tags))
+}
+
+// IncDeadlock sends <namespace>.lock.deadlocks as a counter, tagged by category.
+func (s *StatsDSink) IncDeadlock(category string) {
+	s.send(fmt.Sprintf("%s.lock.deadlocks:1|c|#category:%s", s.namespace, category))
+}
+
+// Close releases the underlying UDP socket.
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *StatsDSink) send(msg string) {
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		log.Printf("glockmon/metrics: statsd send failed: %v", err)
+	}
+}
@@ -1,11 +1,27 @@
 package glockmon
 
 import (
+	"container/list"
+	"errors"
+	"fmt"
 	"github.com/iokiris/glockmon/config"
+	"github.com/iokiris/glockmon/eventlog"
+	"github.com/iokiris/glockmon/metrics"
+	"io"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Lock type labels used by LockInfo.Type and HolderInfo.Type to distinguish
+// reader locks from writer locks on a MonitoredRWMutex. Plain MonitoredMutex
+// locks are always reported as LockTypeWrite.
+const (
+	LockTypeRead  = "READ"
+	LockTypeWrite = "WRITE"
+)
+
 // LockInfo holds information about a mutex lock event.
 // It tracks when the lock was acquired, how long we waited,
 // the stack trace at that moment, and the category for grouping.
@@ -14,33 +30,111 @@ import (
 // Wait: how long it took to get the lock.
 // Stack: the call stack as a string.
 // Category: a label to group similar locks.
+// Type: LockTypeRead or LockTypeWrite, depending on which side of a
+//
+//	MonitoredRWMutex was waited on.
+//
+// GoroutineID: id of the blocked goroutine, populated for deadlock reports.
+// HolderGoroutineID: id of the goroutine holding the lock at the time a
+//
+//	deadlock was suspected, when known. Zero if unknown.
+//
+// AllStacks: stacks of every goroutine at the time a deadlock was
+//
+//	suspected, captured by the Lock watchdog. Empty for ordinary long-lock
+//	events.
 type LockInfo struct {
-	Timestamp time.Time
-	Wait      time.Duration
-	Stack     string
-	Category  string
+	Timestamp         time.Time
+	Wait              time.Duration
+	Stack             string
+	Category          string
+	Type              string
+	GoroutineID       uint64
+	HolderGoroutineID uint64
+	AllStacks         string
 }
 
-// CategoryStats keeps summary data about locks within a category.
+// TypeStats keeps summary data about locks of a single type (read or write)
+// within a category.
 //
 // Count: number of recorded locks.
 // TotalWait: total wait time summed across all locks.
 // AverageWait: average wait time per lock.
-type CategoryStats struct {
+type TypeStats struct {
 	Count       int
 	TotalWait   time.Duration
 	AverageWait time.Duration
 }
 
+// CategoryStats keeps summary data about locks within a category, split by
+// lock type so RLock and Lock contention can be told apart.
+//
+// Read: stats for LockTypeRead events.
+// Write: stats for LockTypeWrite events.
+type CategoryStats struct {
+	Read  TypeStats
+	Write TypeStats
+}
+
+// HolderInfo describes a goroutine currently holding a MonitoredRWMutex,
+// as opposed to LockInfo which describes a past wait event.
+//
+// GoroutineID: id of the holding goroutine, parsed from its stack trace.
+// AcquiredAt: when the holder acquired the lock.
+// Type: LockTypeRead or LockTypeWrite.
+type HolderInfo struct {
+	GoroutineID uint64
+	AcquiredAt  time.Time
+	Type        string
+}
+
+// OnDeadlockFunc is called whenever the Monitor promotes a lock into the
+// deadlocks set, reported by a Lock watchdog.
+type OnDeadlockFunc func(LockInfo)
+
+// StackRecord aggregates every long-lock event recorded for a single call
+// site (identified by its stack trace), rather than keeping one entry per
+// event. This bounds memory use under sustained lock contention, since a
+// hot call site no longer grows the tracked set with every occurrence.
+//
+// Stack: the call stack as a string, stored once per call site.
+// Category: the category label of the events aggregated here.
+// Type: LockTypeRead or LockTypeWrite.
+// Count: number of events aggregated into this record.
+// TotalWait: sum of wait durations across all aggregated events.
+// MaxWait: longest wait duration seen for this call site.
+// LastSeen: when the most recent event was recorded.
+type StackRecord struct {
+	Stack     string
+	Category  string
+	Type      string
+	Count     int
+	TotalWait time.Duration
+	MaxWait   time.Duration
+	LastSeen  time.Time
+}
+
 // Monitor tracks long mutex locks, saving their info,
 // including stack traces and wait times, and maintains stats by category.
 type Monitor struct {
-	mu              sync.Mutex
-	longLocks       map[uint64]LockInfo       // key: stack hash, value: lock info
-	stackCache      map[uint64]string         // key: stack hash, value: stack string
-	keepRecords     bool                      // whether to keep records or not
-	defaultCategory string                    // fallback category name
-	categoryStats   map[string]*CategoryStats // stats per category
+	mu                 sync.Mutex
+	stacks             map[uint64]*list.Element  // key: stack hash, value: node holding a *StackRecord
+	lru                *list.List                // front: most recently seen stack; back: least recently seen
+	maxTrackedStacks   int                       // cap on len(stacks); oldest entries are evicted past this
+	staleWindow        time.Duration             // how old LastSeen must be for the ?stale=true filter
+	deadlockStackCache map[uint64]string         // key: stack hash, value: stack string, for deadlock-only captures
+	keepRecords        bool                      // whether to keep records or not
+	defaultCategory    string                    // fallback category name
+	categoryStats      map[string]*CategoryStats // stats per category
+	holders            map[uint64][]HolderInfo   // key: lock id, value: current holders
+	lockIDSeq          uint64                    // source for NewLockID
+	deadlocks          map[uint64]LockInfo       // key: stack hash, value: suspected deadlock
+	deadlockThreshold  time.Duration             // how long before a lock is suspected of deadlock
+	deadlockPolicy     config.DeadlockPolicy     // what to do once a deadlock is detected
+	deadlockHandlers   []OnDeadlockFunc          // user-registered deadlock callbacks
+	sinks              []metrics.Sink            // push-based metrics sinks fanned out to on every event
+	eventLog           *eventlog.Writer          // optional rolling event log, nil if not configured
+	eventLogCfg        eventlog.Config           // kept to serve the Events endpoint
 }
 
 // NewMonitor creates and returns a new Monitor instance configured by cfg.
@@ -54,13 +148,54 @@ type Monitor struct {
 // providing information about lock events and categories.
 //
 // The HTTP server runs in a separate goroutine and listens on the configured address.
+//
+// If cfg.DeadlockThreshold is positive, every MonitoredMutex.Lock call arms
+// a watchdog that reports a suspected deadlock if the call is still blocked
+// after that long; see MonitoredMutex.Lock.
+//
+// cfg.MaxTrackedStacks bounds the number of distinct call sites kept in
+// memory, defaulting to 10_000 if unset; the least-recently-seen call site
+// is evicted once the cap is reached.
+//
+// If cfg.EventLog.Path is set, every recorded event is also appended to a
+// rotating on-disk log, queryable via the Events endpoint. If it fails to
+// open, the error is logged and the Monitor proceeds without an event log.
 func NewMonitor(cfg *config.MonitorConfig) *Monitor {
+	maxTrackedStacks := cfg.MaxTrackedStacks
+	if maxTrackedStacks <= 0 {
+		maxTrackedStacks = 10_000
+	}
+	staleWindow := cfg.StaleWindow
+	if staleWindow <= 0 {
+		staleWindow = 5 * time.Minute
+	}
+
+	var eventLog *eventlog.Writer
+	if cfg.EventLog.Path != "" {
+		w, err := eventlog.NewWriter(cfg.EventLog)
+		if err != nil {
+			log.Printf("glockmon: failed to open event log: %v", err)
+		} else {
+			eventLog = w
+		}
+	}
+
 	monitor := &Monitor{
-		longLocks:       make(map[uint64]LockInfo),
-		stackCache:      make(map[uint64]string),
-		categoryStats:   make(map[string]*CategoryStats),
-		keepRecords:     cfg.KeepRecords,
-		defaultCategory: cfg.DefaultCategory,
+		stacks:             make(map[uint64]*list.Element),
+		lru:                list.New(),
+		maxTrackedStacks:   maxTrackedStacks,
+		staleWindow:        staleWindow,
+		deadlockStackCache: make(map[uint64]string),
+		categoryStats:      make(map[string]*CategoryStats),
+		holders:            make(map[uint64][]HolderInfo),
+		deadlocks:          make(map[uint64]LockInfo),
+		keepRecords:        cfg.KeepRecords,
+		defaultCategory:    cfg.DefaultCategory,
+		deadlockThreshold:  cfg.DeadlockThreshold,
+		deadlockPolicy:     cfg.DeadlockPolicy,
+		sinks:              cfg.Sinks,
+		eventLog:           eventLog,
+		eventLogCfg:        cfg.EventLog,
 	}
 
 	server := NewHTTPServer(cfg, monitor)
@@ -69,20 +204,49 @@ func NewMonitor(cfg *config.MonitorConfig) *Monitor {
 	return monitor
 }
 
-// Add records a new long lock event.
+// Add records a new long lock event, aggregating it into the StackRecord
+// for its call site.
 //
 // stack: the call stack at lock time.
 // info: details about the lock (timestamp, wait duration, category).
 //
-// Updates internal stats and caches the stack for later reference.
+// Updates internal stats, aggregates the event into its call site's
+// StackRecord (evicting the least-recently-seen call site if this one is
+// new and MaxTrackedStacks is exceeded), fans the event out to every
+// registered metrics sink, and appends it to the event log if configured.
+// CategoryStats always reflects every event regardless of eviction, since
+// it's updated here rather than rolled up from StackRecord at eviction time.
 func (m *Monitor) Add(stack string, info LockInfo) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	h := hashStack(stack)
-	info.Stack = stack
-	m.longLocks[h] = info
-	m.stackCache[h] = stack
+	if elem, ok := m.stacks[h]; ok {
+		rec := elem.Value.(*StackRecord)
+		rec.Count++
+		rec.TotalWait += info.Wait
+		if info.Wait > rec.MaxWait {
+			rec.MaxWait = info.Wait
+		}
+		rec.LastSeen = info.Timestamp
+		rec.Category = info.Category
+		rec.Type = info.Type
+		m.lru.MoveToFront(elem)
+	} else {
+		rec := &StackRecord{
+			Stack:     stack,
+			Category:  info.Category,
+			Type:      info.Type,
+			Count:     1,
+			TotalWait: info.Wait,
+			MaxWait:   info.Wait,
+			LastSeen:  info.Timestamp,
+		}
+		m.stacks[h] = m.lru.PushFront(rec)
+
+		if len(m.stacks) > m.maxTrackedStacks {
+			m.evictLRU()
+		}
+	}
 
 	stats, exists := m.categoryStats[info.Category]
 	if !exists {
@@ -90,9 +254,41 @@ func (m *Monitor) Add(stack string, info LockInfo) {
 		m.categoryStats[info.Category] = stats
 	}
 
-	stats.Count++
-	stats.TotalWait += info.Wait
-	stats.AverageWait = stats.TotalWait / time.Duration(stats.Count)
+	ts := &stats.Write
+	if info.Type == LockTypeRead {
+		ts = &stats.Read
+	}
+	ts.Count++
+	ts.TotalWait += info.Wait
+	ts.AverageWait = ts.TotalWait / time.Duration(ts.Count)
+
+	m.mu.Unlock()
+
+	for _, sink := range m.sinks {
+		sink.ObserveLockWait(info.Category, info.Type, info.Wait)
+	}
+
+	if m.eventLog != nil {
+		m.eventLog.Write(eventlog.Entry{
+			Timestamp: info.Timestamp,
+			Wait:      info.Wait,
+			Category:  info.Category,
+			Type:      info.Type,
+			Stack:     stack,
+		})
+	}
+}
+
+// evictLRU drops the least-recently-seen StackRecord. Callers must hold m.mu.
+func (m *Monitor) evictLRU() {
+	back := m.lru.Back()
+	if back == nil {
+		return
+	}
+
+	rec := back.Value.(*StackRecord)
+	m.lru.Remove(back)
+	delete(m.stacks, hashStack(rec.Stack))
 }
 
 // RemoveByStack deletes a lock record given its stack trace.
@@ -105,25 +301,34 @@ func (m *Monitor) RemoveByStack(stack string) {
 	defer m.mu.Unlock()
 
 	h := hashStack(stack)
-	delete(m.longLocks, h)
-	delete(m.stackCache, h)
+	if elem, ok := m.stacks[h]; ok {
+		m.lru.Remove(elem)
+		delete(m.stacks, h)
+	}
 }
 
 // Snapshot returns a copy of all current long lock records.
 //
-// Returns a map keyed by stack hash, with LockInfo as values.
-func (m *Monitor) Snapshot() map[uint64]LockInfo {
+// Returns a map keyed by stack hash, with StackRecord as values.
+func (m *Monitor) Snapshot() map[uint64]StackRecord {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	copyMap := make(map[uint64]LockInfo, len(m.longLocks))
-	for k, v := range m.longLocks {
-		copyMap[k] = v
+	copyMap := make(map[uint64]StackRecord, len(m.stacks))
+	for k, elem := range m.stacks {
+		copyMap[k] = *elem.Value.(*StackRecord)
 	}
 	return copyMap
 }
 
-// GetStack fetches the stack trace string for a given hash.
+// StaleWindow returns the configured staleness window used by the
+// /blocked?stale=true filter.
+func (m *Monitor) StaleWindow() time.Duration {
+	return m.staleWindow
+}
+
+// GetStack fetches the stack trace string for a given hash, whether it
+// belongs to a currently tracked StackRecord or to a deadlock-only capture.
 //
 // hash: the hash key of the stack trace.
 //
@@ -132,11 +337,15 @@ func (m *Monitor) GetStack(hash uint64) (string, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	s, ok := m.stackCache[hash]
+	if elem, ok := m.stacks[hash]; ok {
+		return elem.Value.(*StackRecord).Stack, true
+	}
+	s, ok := m.deadlockStackCache[hash]
 	return s, ok
 }
 
-// GetStackCache returns a copy of the full stack trace cache.
+// GetStackCache returns a copy of the full stack trace cache, covering both
+// tracked StackRecords and deadlock-only captures.
 //
 // Useful if you want to inspect or export all cached stacks.
 //
@@ -145,8 +354,11 @@ func (m *Monitor) GetStackCache() map[uint64]string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	copyCache := make(map[uint64]string, len(m.stackCache))
-	for k, v := range m.stackCache {
+	copyCache := make(map[uint64]string, len(m.stacks)+len(m.deadlockStackCache))
+	for k, elem := range m.stacks {
+		copyCache[k] = elem.Value.(*StackRecord).Stack
+	}
+	for k, v := range m.deadlockStackCache {
 		copyCache[k] = v
 	}
 	return copyCache
@@ -165,3 +377,134 @@ func (m *Monitor) GetCategoryStats() map[string]CategoryStats {
 	}
 	return result
 }
+
+// StreamEvents writes every persisted lock event after since (or all, if
+// since is zero) to out as newline-delimited JSON, optionally filtered to a
+// single category, stopping once limit entries have been written (limit <=
+// 0 means no limit). Returns an error if no event log is configured.
+func (m *Monitor) StreamEvents(out io.Writer, since time.Time, category string, limit int) error {
+	if m.eventLogCfg.Path == "" {
+		return errors.New("glockmon: event log not configured")
+	}
+	return eventlog.Stream(m.eventLogCfg, out, since, category, limit)
+}
+
+// Close releases resources owned by the Monitor, currently limited to
+// flushing and closing the event log file, if one is configured. Safe to
+// call even when no event log is configured.
+func (m *Monitor) Close() error {
+	if m.eventLog == nil {
+		return nil
+	}
+	return m.eventLog.Close()
+}
+
+// NewLockID hands out a unique id for a MonitoredRWMutex instance, used to
+// key its holder set independently of any particular call site's stack hash.
+func (m *Monitor) NewLockID() uint64 {
+	return atomic.AddUint64(&m.lockIDSeq, 1)
+}
+
+// AddHolder records that a goroutine currently holds lockID, either for
+// reading or writing.
+func (m *Monitor) AddHolder(lockID uint64, holder HolderInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.holders[lockID] = append(m.holders[lockID], holder)
+}
+
+// RemoveHolder drops the holder entry for goroutineID on lockID, e.g. on
+// RUnlock/Unlock. It is a no-op if no matching holder is tracked.
+func (m *Monitor) RemoveHolder(lockID uint64, goroutineID uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	holders := m.holders[lockID]
+	for i, h := range holders {
+		if h.GoroutineID == goroutineID {
+			m.holders[lockID] = append(holders[:i], holders[i+1:]...)
+			break
+		}
+	}
+}
+
+// GetHolders returns a copy of the current holder set for lockID.
+func (m *Monitor) GetHolders(lockID uint64) []HolderInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	holders := m.holders[lockID]
+	result := make([]HolderInfo, len(holders))
+	copy(result, holders)
+	return result
+}
+
+// OnDeadlock registers fn to be called whenever a lock is promoted into the
+// deadlocks set. Multiple handlers may be registered; all are called in
+// registration order.
+func (m *Monitor) OnDeadlock(fn OnDeadlockFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deadlockHandlers = append(m.deadlockHandlers, fn)
+}
+
+// GetDeadlocks returns a snapshot of all currently suspected deadlocks.
+func (m *Monitor) GetDeadlocks() map[uint64]LockInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[uint64]LockInfo, len(m.deadlocks))
+	for k, v := range m.deadlocks {
+		result[k] = v
+	}
+	return result
+}
+
+// reportDeadlockSuspect is called by a Lock watchdog when a call has been
+// blocked longer than deadlockThreshold. info.Stack identifies the entry;
+// duplicate reports for the same stack are ignored.
+func (m *Monitor) reportDeadlockSuspect(info LockInfo) {
+	h := hashStack(info.Stack)
+
+	m.mu.Lock()
+	if _, exists := m.deadlocks[h]; exists {
+		m.mu.Unlock()
+		return
+	}
+	m.deadlocks[h] = info
+	m.deadlockStackCache[h] = info.Stack
+	if info.AllStacks != "" {
+		m.deadlockStackCache[hashStack(info.AllStacks)] = info.AllStacks
+	}
+	m.mu.Unlock()
+
+	m.raiseDeadlock(info)
+}
+
+// raiseDeadlock applies the configured DeadlockPolicy, fans the deadlock
+// out to every registered metrics sink, and notifies every registered
+// OnDeadlock handler.
+func (m *Monitor) raiseDeadlock(info LockInfo) {
+	if m.deadlockPolicy == config.DeadlockPolicyLog {
+		log.Printf("glockmon: suspected deadlock in category %q, acquired %s", info.Category, info.Timestamp)
+	}
+
+	for _, sink := range m.sinks {
+		sink.IncDeadlock(info.Category)
+	}
+
+	m.mu.Lock()
+	handlers := append([]OnDeadlockFunc(nil), m.deadlockHandlers...)
+	m.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(info)
+	}
+
+	// DeadlockPolicyCrash panics only after sinks and handlers have seen it.
+	if m.deadlockPolicy == config.DeadlockPolicyCrash {
+		panic(fmt.Sprintf("glockmon: suspected deadlock in category %q, acquired %s", info.Category, info.Timestamp))
+	}
+}
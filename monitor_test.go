@@ -0,0 +1,124 @@
+package glockmon
+
+import (
+	"container/list"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestMonitor builds a bare Monitor for unit tests, skipping NewMonitor's
+// HTTP server and event log setup since these tests only exercise in-memory
+// bookkeeping.
+func newTestMonitor(maxTrackedStacks int) *Monitor {
+	return &Monitor{
+		stacks:             make(map[uint64]*list.Element),
+		lru:                list.New(),
+		maxTrackedStacks:   maxTrackedStacks,
+		staleWindow:        5 * time.Minute,
+		deadlockStackCache: make(map[uint64]string),
+		categoryStats:      make(map[string]*CategoryStats),
+		holders:            make(map[uint64][]HolderInfo),
+		deadlocks:          make(map[uint64]LockInfo),
+		defaultCategory:    "GLOBAL",
+	}
+}
+
+// TestMonitorAddAggregatesStackRecord checks that repeated events for the
+// same call site roll up into a single StackRecord instead of one entry per
+// event.
+func TestMonitorAddAggregatesStackRecord(t *testing.T) {
+	m := newTestMonitor(10)
+
+	waits := []time.Duration{5 * time.Millisecond, 15 * time.Millisecond, 10 * time.Millisecond}
+	for _, w := range waits {
+		m.Add("stack-a", LockInfo{
+			Timestamp: time.Now(),
+			Wait:      w,
+			Category:  "cat1",
+			Type:      LockTypeWrite,
+		})
+	}
+
+	snap := m.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("len(snapshot) = %d, want 1", len(snap))
+	}
+	rec := snap[hashStack("stack-a")]
+	if rec.Count != len(waits) {
+		t.Fatalf("Count = %d, want %d", rec.Count, len(waits))
+	}
+	wantTotal := 30 * time.Millisecond
+	if rec.TotalWait != wantTotal {
+		t.Fatalf("TotalWait = %v, want %v", rec.TotalWait, wantTotal)
+	}
+	wantMax := 15 * time.Millisecond
+	if rec.MaxWait != wantMax {
+		t.Fatalf("MaxWait = %v, want %v", rec.MaxWait, wantMax)
+	}
+}
+
+// TestMonitorEvictionPreservesCategoryStats checks that evicting a
+// least-recently-seen StackRecord past MaxTrackedStacks drops it from
+// Snapshot, but CategoryStats still reflects every event recorded for it,
+// since CategoryStats is updated directly in Add rather than rolled up from
+// StackRecords at eviction time.
+func TestMonitorEvictionPreservesCategoryStats(t *testing.T) {
+	m := newTestMonitor(2)
+
+	stacks := []string{"stack-a", "stack-b", "stack-c"}
+	for _, s := range stacks {
+		m.Add(s, LockInfo{
+			Timestamp: time.Now(),
+			Wait:      10 * time.Millisecond,
+			Category:  "cat1",
+			Type:      LockTypeWrite,
+		})
+	}
+
+	snap := m.Snapshot()
+	if len(snap) != 2 {
+		t.Fatalf("len(snapshot) = %d, want 2 (cap)", len(snap))
+	}
+	if _, ok := snap[hashStack("stack-a")]; ok {
+		t.Fatalf("stack-a should have been evicted as least-recently-seen")
+	}
+	for _, s := range []string{"stack-b", "stack-c"} {
+		if _, ok := snap[hashStack(s)]; !ok {
+			t.Fatalf("%s should still be tracked", s)
+		}
+	}
+
+	stats := m.GetCategoryStats()["cat1"]
+	if stats.Write.Count != len(stacks) {
+		t.Fatalf("Write.Count = %d, want %d (eviction must not drop category stats)", stats.Write.Count, len(stacks))
+	}
+	wantTotal := time.Duration(len(stacks)) * 10 * time.Millisecond
+	if stats.Write.TotalWait != wantTotal {
+		t.Fatalf("Write.TotalWait = %v, want %v", stats.Write.TotalWait, wantTotal)
+	}
+}
+
+// TestMonitorHolderAddRemoveConcurrent exercises AddHolder/RemoveHolder from
+// many goroutines at once, so races on the shared holders map surface under
+// go test -race.
+func TestMonitorHolderAddRemoveConcurrent(t *testing.T) {
+	m := newTestMonitor(10)
+	lockID := m.NewLockID()
+
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(gid uint64) {
+			defer wg.Done()
+			m.AddHolder(lockID, HolderInfo{GoroutineID: gid, AcquiredAt: time.Now(), Type: LockTypeRead})
+			m.RemoveHolder(lockID, gid)
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	if holders := m.GetHolders(lockID); len(holders) != 0 {
+		t.Fatalf("GetHolders = %v, want empty after every AddHolder was paired with a RemoveHolder", holders)
+	}
+}
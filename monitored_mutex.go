@@ -2,6 +2,7 @@ package glockmon
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +18,7 @@ type MonitoredMutex struct {
 	monitor   *Monitor
 	lockedKey uint64
 	category  string
+	holderGID uint64 // atomic: goroutine id of the current holder, 0 if unlocked
 }
 
 // New creates a new MonitoredMutex that reports to the given Monitor.
@@ -49,12 +51,40 @@ func (m *MonitoredMutex) SetCategory(category string) {
 // If the wait exceeds the configured threshold, the lock event with
 // stack trace and category info is reported to the Monitor.
 //
+// If the Monitor has deadlock detection enabled and the wait exceeds
+// DeadlockThreshold, a watchdog captures the stacks of every goroutine and
+// reports a suspected deadlock, carrying this goroutine's id and, when
+// known, the id of the goroutine currently holding the mutex.
+//
 // This helps to identify and track long lock occurrences.
 func (m *MonitoredMutex) Lock() {
 	start := time.Now()
+	gid := currentGoroutineID()
+
+	var watchdog *time.Timer
+	if m.monitor != nil && m.monitor.deadlockThreshold > 0 {
+		watchdog = time.AfterFunc(m.monitor.deadlockThreshold, func() {
+			stack := getStackTrace()
+			m.monitor.reportDeadlockSuspect(LockInfo{
+				Timestamp:         time.Now(),
+				Wait:              time.Since(start),
+				Stack:             stack,
+				Category:          m.category,
+				Type:              LockTypeWrite,
+				GoroutineID:       gid,
+				HolderGoroutineID: atomic.LoadUint64(&m.holderGID),
+				AllStacks:         allStacks(),
+			})
+		})
+	}
 
 	m.mu.Lock()
 
+	if watchdog != nil {
+		watchdog.Stop()
+	}
+	atomic.StoreUint64(&m.holderGID, gid)
+
 	wait := time.Since(start)
 	if wait > m.threshold && m.monitor != nil {
 		stack := getStackTrace()
@@ -67,6 +97,7 @@ func (m *MonitoredMutex) Lock() {
 			Wait:      wait,
 			Stack:     stack,
 			Category:  m.category,
+			Type:      LockTypeWrite,
 		})
 	} else {
 		m.lockedKey = 0
@@ -78,6 +109,7 @@ func (m *MonitoredMutex) Lock() {
 // If the Monitor does not keep records, it removes the tracked lock info for this mutex
 // after unlocking, cleaning up memory for short-lived lock records.
 func (m *MonitoredMutex) Unlock() {
+	atomic.StoreUint64(&m.holderGID, 0)
 	if m.monitor != nil && !m.monitor.keepRecords && m.lockedKey != 0 {
 		if stack, ok := m.monitor.GetStack(m.lockedKey); ok {
 			m.monitor.RemoveByStack(stack)
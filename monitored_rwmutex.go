@@ -0,0 +1,190 @@
+package glockmon
+
+import (
+	"sync"
+	"time"
+)
+
+// MonitoredRWMutex is a sync.RWMutex wrapper that tracks read and write wait
+// times separately and reports long locks to a Monitor if the wait exceeds
+// a configured threshold, mirroring MonitoredMutex but for the read/write
+// split.
+//
+// It also keeps track of the goroutines currently holding the lock, since
+// an RWMutex can have many concurrent readers and operators often need to
+// know who is holding it right now, not just who waited on it.
+//
+// Use NewRW to create an instance.
+type MonitoredRWMutex struct {
+	mu        sync.RWMutex
+	threshold time.Duration
+	monitor   *Monitor
+	id        uint64
+	category  string
+	lockedKey uint64
+}
+
+// NewRW creates a new MonitoredRWMutex that reports to the given Monitor.
+// threshold sets the minimum duration a Lock or RLock must wait before it
+// is considered "long".
+//
+// monitor: the Monitor instance to report long lock info and holders to.
+// threshold: minimum lock wait time to trigger monitoring.
+func NewRW(monitor *Monitor, threshold time.Duration) *MonitoredRWMutex {
+	return &MonitoredRWMutex{
+		threshold: threshold,
+		monitor:   monitor,
+		category:  monitor.defaultCategory,
+		id:        monitor.NewLockID(),
+	}
+}
+
+// SetCategory changes the category label for this mutex's lock events.
+// Use this to group locks by logical categories.
+//
+// If an empty string is passed, the default category from the Monitor is used.
+func (m *MonitoredRWMutex) SetCategory(category string) {
+	if category == "" {
+		m.category = m.monitor.defaultCategory
+	} else {
+		m.category = category
+	}
+}
+
+// ID returns the identifier used to look up this mutex's current holders,
+// e.g. via the /holders/{id} HTTP endpoint.
+func (m *MonitoredRWMutex) ID() uint64 {
+	return m.id
+}
+
+// Lock acquires the mutex for writing and measures the wait time.
+//
+// If the wait exceeds the configured threshold, the lock event with stack
+// trace and category info is reported to the Monitor. The calling goroutine
+// is also recorded as the current holder until Unlock is called.
+//
+// If the Monitor has deadlock detection enabled and the wait exceeds
+// DeadlockThreshold, a watchdog captures the stacks of every goroutine and
+// reports a suspected deadlock, carrying this goroutine's id and, when a
+// current holder is known, its id, mirroring MonitoredMutex.Lock.
+func (m *MonitoredRWMutex) Lock() {
+	start := time.Now()
+	gid := currentGoroutineID()
+
+	var watchdog *time.Timer
+	if m.monitor != nil && m.monitor.deadlockThreshold > 0 {
+		watchdog = time.AfterFunc(m.monitor.deadlockThreshold, func() {
+			var holderGID uint64
+			if holders := m.monitor.GetHolders(m.id); len(holders) > 0 {
+				holderGID = holders[0].GoroutineID
+			}
+			m.monitor.reportDeadlockSuspect(LockInfo{
+				Timestamp:         time.Now(),
+				Wait:              time.Since(start),
+				Stack:             getStackTrace(),
+				Category:          m.category,
+				Type:              LockTypeWrite,
+				GoroutineID:       gid,
+				HolderGoroutineID: holderGID,
+				AllStacks:         allStacks(),
+			})
+		})
+	}
+
+	m.mu.Lock()
+
+	if watchdog != nil {
+		watchdog.Stop()
+	}
+
+	if m.monitor != nil {
+		m.monitor.AddHolder(m.id, HolderInfo{
+			GoroutineID: gid,
+			AcquiredAt:  time.Now(),
+			Type:        LockTypeWrite,
+		})
+	}
+
+	wait := time.Since(start)
+	if wait > m.threshold && m.monitor != nil {
+		stack := getStackTrace()
+		key := hashStack(stack)
+		m.lockedKey = key
+
+		m.monitor.Add(stack, LockInfo{
+			Timestamp: time.Now(),
+			Wait:      wait,
+			Stack:     stack,
+			Category:  m.category,
+			Type:      LockTypeWrite,
+		})
+	} else {
+		m.lockedKey = 0
+	}
+}
+
+// Unlock releases the write lock and clears this goroutine's holder entry.
+//
+// If the Monitor does not keep records, it also removes the tracked lock
+// info for this mutex, cleaning up memory for short-lived lock records.
+func (m *MonitoredRWMutex) Unlock() {
+	if m.monitor != nil {
+		m.monitor.RemoveHolder(m.id, currentGoroutineID())
+
+		if !m.monitor.keepRecords && m.lockedKey != 0 {
+			if stack, ok := m.monitor.GetStack(m.lockedKey); ok {
+				m.monitor.RemoveByStack(stack)
+			}
+			m.lockedKey = 0
+		}
+	}
+	m.mu.Unlock()
+}
+
+// RLock acquires the mutex for reading and measures the wait time.
+//
+// If the wait exceeds the configured threshold, the lock event is reported
+// to the Monitor as a LockTypeRead event. The calling goroutine is recorded
+// as a current holder alongside any other concurrent readers until RUnlock
+// is called.
+//
+// Unlike Lock/Unlock, RUnlock never removes the StackRecord created here,
+// even when KeepRecords is false: concurrent readers share this
+// MonitoredRWMutex's single lockedKey field, so there's no race-free way for
+// an RUnlock call to know which reader's record it would be safe to remove.
+// Read records are instead reclaimed like any other call site, via LRU
+// eviction once MaxTrackedStacks is exceeded.
+func (m *MonitoredRWMutex) RLock() {
+	start := time.Now()
+	gid := currentGoroutineID()
+
+	m.mu.RLock()
+
+	if m.monitor != nil {
+		m.monitor.AddHolder(m.id, HolderInfo{
+			GoroutineID: gid,
+			AcquiredAt:  time.Now(),
+			Type:        LockTypeRead,
+		})
+
+		if wait := time.Since(start); wait > m.threshold {
+			stack := getStackTrace()
+			m.monitor.Add(stack, LockInfo{
+				Timestamp: time.Now(),
+				Wait:      wait,
+				Stack:     stack,
+				Category:  m.category,
+				Type:      LockTypeRead,
+			})
+		}
+	}
+}
+
+// RUnlock releases the read lock and clears this goroutine's holder entry.
+// See RLock for why it does not remove any StackRecord.
+func (m *MonitoredRWMutex) RUnlock() {
+	if m.monitor != nil {
+		m.monitor.RemoveHolder(m.id, currentGoroutineID())
+	}
+	m.mu.RUnlock()
+}
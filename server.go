@@ -4,54 +4,116 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/iokiris/glockmon/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // HTTPServer manages the lifecycle of an HTTP server exposing monitoring data.
 //
-// It serves three main endpoints:
+// It serves these endpoints:
 //
-//	GET /blocked    - returns JSON list of currently tracked long locks
-//	GET /stacks/{id} - returns the stack trace text for a given lock id
-//	GET /categories - returns JSON statistics aggregated by categories
+//	GET /blocked        - returns JSON list of currently tracked long-lock
+//	                      call sites, filterable with ?type=read|write and
+//	                      ?stale=true, orderable with ?sort=wait|count|recent
+//	                      and ?top=N
+//	GET /stacks/{id}    - returns the stack trace text for a given lock id
+//	GET /categories     - returns JSON statistics aggregated by categories
+//	GET /holders/{id}   - returns JSON list of current holders of a
+//	                      MonitoredRWMutex by its lock id
+//	GET /deadlocks      - returns JSON list of suspected deadlocks
+//	GET /metrics        - Prometheus scrape endpoint (only mounted if
+//	                      config.HTTPConfig.Metrics is non-empty), serving
+//	                      the registry of a configured metrics.PrometheusSink
+//	                      if one is found among cfg.Sinks, or
+//	                      prometheus.DefaultRegisterer otherwise
+//	GET /events         - streams persisted lock events as newline-delimited
+//	                      JSON (application/x-ndjson), filterable with
+//	                      ?since=<unixnano>&category=<cat>&limit=N
 //
 // This server is intended for internal monitoring and debugging purposes.
 //
 // Create an instance with NewHTTPServer and call Start() to run it in background.
 type HTTPServer struct {
-	addr    string
-	monitor *Monitor
-	server  *http.Server
-	mu      sync.Mutex
-	running bool
+	addr            string
+	monitor         *Monitor
+	server          *http.Server
+	mu              sync.Mutex
+	running         bool
+	metricsGatherer prometheus.Gatherer // non-nil if a sink among cfg.Sinks exposes one
 
 	endpoints struct {
 		Blocked    string
 		Categories string
 		Stack      string
+		Holders    string
+		Deadlocks  string
+		Metrics    string
+		Events     string
 	}
 }
 
-// BlockedEntry represents a single long lock event returned by the config.HTTPConfig endpoint.
+// BlockedEntry represents the aggregated long-lock events for a single call
+// site, returned by the config.HTTPConfig Blocked endpoint.
 type BlockedEntry struct {
-	ID        uint64 `json:"id,string"`
-	Category  string `json:"category"`
-	WaitMs    int64  `json:"wait_ms"`
-	Timestamp int64  `json:"timestamp,string"` // UnixNano
+	ID          uint64 `json:"id,string"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+	Count       int    `json:"count"`
+	TotalWaitMs int64  `json:"total_wait_ms"`
+	MaxWaitMs   int64  `json:"max_wait_ms"`
+	LastSeen    int64  `json:"last_seen,string"` // UnixNano
 }
 
-// CategoryStatsResponse represents aggregated lock stats by category returned by the config.HTTPConfig endpoint.
-type CategoryStatsResponse struct {
-	Category    string  `json:"category"`
+// TypeStatsResponse represents aggregated lock stats for a single lock type.
+type TypeStatsResponse struct {
 	Count       int     `json:"count"`
 	AverageWait float64 `json:"average_wait_ms"`
 	TotalWait   int64   `json:"total_wait_ms"`
 }
 
+// CategoryStatsResponse represents aggregated lock stats by category returned by the config.HTTPConfig endpoint.
+type CategoryStatsResponse struct {
+	Category string            `json:"category"`
+	Read     TypeStatsResponse `json:"read"`
+	Write    TypeStatsResponse `json:"write"`
+}
+
+// HolderEntry represents a single current holder of a MonitoredRWMutex.
+type HolderEntry struct {
+	GoroutineID uint64 `json:"goroutine_id,string"`
+	Type        string `json:"type"`
+	AcquiredAt  int64  `json:"acquired_at,string"` // UnixNano
+}
+
+// DeadlockEntry represents a single suspected deadlock. StackID and
+// AllStacksID reference entries fetchable via the Stack endpoint;
+// AllStacksID is zero when no all-goroutine dump was captured.
+type DeadlockEntry struct {
+	ID                uint64 `json:"id,string"`
+	Category          string `json:"category"`
+	Type              string `json:"type"`
+	WaitMs            int64  `json:"wait_ms"`
+	Timestamp         int64  `json:"timestamp,string"` // UnixNano
+	GoroutineID       uint64 `json:"goroutine_id,string"`
+	HolderGoroutineID uint64 `json:"holder_goroutine_id,string"`
+	StackID           uint64 `json:"stack_id,string"`
+	AllStacksID       uint64 `json:"all_stacks_id,string"`
+}
+
+// prometheusGatherer is implemented by metrics sinks whose collectors can
+// be scraped directly, e.g. metrics.PrometheusSink. NewHTTPServer uses it to
+// serve that sink's own registry from the Metrics endpoint.
+type prometheusGatherer interface {
+	Gatherer() prometheus.Gatherer
+}
+
 // NewHTTPServer creates a new HTTPServer from the Monitor instance.
 func NewHTTPServer(cfg *config.MonitorConfig, monitor *Monitor) *HTTPServer {
 	s := &HTTPServer{
@@ -62,6 +124,17 @@ func NewHTTPServer(cfg *config.MonitorConfig, monitor *Monitor) *HTTPServer {
 	s.endpoints.Blocked = cfg.HTTPEndpoints.Blocked
 	s.endpoints.Categories = cfg.HTTPEndpoints.Categories
 	s.endpoints.Stack = cfg.HTTPEndpoints.Stack
+	s.endpoints.Holders = cfg.HTTPEndpoints.Holders
+	s.endpoints.Deadlocks = cfg.HTTPEndpoints.Deadlocks
+	s.endpoints.Metrics = cfg.HTTPEndpoints.Metrics
+	s.endpoints.Events = cfg.HTTPEndpoints.Events
+
+	for _, sink := range cfg.Sinks {
+		if g, ok := sink.(prometheusGatherer); ok {
+			s.metricsGatherer = g.Gatherer()
+			break
+		}
+	}
 
 	return s
 
@@ -80,6 +153,16 @@ func (s *HTTPServer) Start() {
 	mux.HandleFunc(s.endpoints.Blocked, s.handleBlocked)
 	mux.HandleFunc(s.endpoints.Categories, s.handleCategories)
 	mux.HandleFunc(s.endpoints.Stack, s.handleStack)
+	mux.HandleFunc(s.endpoints.Holders, s.handleHolders)
+	mux.HandleFunc(s.endpoints.Deadlocks, s.handleDeadlocks)
+	mux.HandleFunc(s.endpoints.Events, s.handleEvents)
+	if s.endpoints.Metrics != "" {
+		if s.metricsGatherer != nil {
+			mux.Handle(s.endpoints.Metrics, promhttp.HandlerFor(s.metricsGatherer, promhttp.HandlerOpts{}))
+		} else {
+			mux.Handle(s.endpoints.Metrics, promhttp.Handler())
+		}
+	}
 
 	s.server = &http.Server{
 		Addr:    s.addr,
@@ -95,28 +178,128 @@ func (s *HTTPServer) Start() {
 	log.Printf("HTTP monitoring server started on %s", s.addr)
 }
 
-// handleBlocked returns a JSON list of all currently tracked long lock events.
+// handleBlocked returns a JSON list of the currently tracked long-lock call
+// sites, aggregated per stack (see Monitor.Add).
+//
+// Each entry contains ID, category, type, event count, total and max wait
+// time in milliseconds, and the UnixNano timestamp it was last seen.
 //
-// Each entry contains ID, category, wait time in milliseconds, timestamp (UnixNano), and stack ID.
+// Query parameters:
+//
+//	type=read|write  restricts the result to that lock type.
+//	stale=true       restricts the result to entries whose LastSeen is
+//	                 older than the Monitor's StaleWindow.
+//	sort=wait|count|recent  orders entries by total wait (default),
+//	                 event count, or most recently seen, all descending.
+//	top=N            limits the result to the first N entries after sorting.
+//
+// Entries are ordered deterministically: ties on the sort criterion break
+// on ascending ID.
 func (s *HTTPServer) handleBlocked(w http.ResponseWriter, r *http.Request) {
-	locksMap := s.monitor.Snapshot()
+	q := r.URL.Query()
+	typeFilter := strings.ToUpper(q.Get("type"))
+	staleOnly := q.Get("stale") == "true"
+	sortBy := q.Get("sort")
+
+	var topN int
+	if v := q.Get("top"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			topN = n
+		}
+	}
 
-	var entries []BlockedEntry
-	for id, info := range locksMap {
+	stacksMap := s.monitor.Snapshot()
+	staleWindow := s.monitor.StaleWindow()
+	now := time.Now()
+
+	entries := make([]BlockedEntry, 0, len(stacksMap))
+	for id, rec := range stacksMap {
+		if typeFilter != "" && rec.Type != typeFilter {
+			continue
+		}
+		if staleOnly && now.Sub(rec.LastSeen) <= staleWindow {
+			continue
+		}
 		entries = append(entries, BlockedEntry{
-			ID:        id,
-			Category:  info.Category,
-			WaitMs:    info.Wait.Milliseconds(),
-			Timestamp: info.Timestamp.UnixNano(),
+			ID:          id,
+			Category:    rec.Category,
+			Type:        rec.Type,
+			Count:       rec.Count,
+			TotalWaitMs: rec.TotalWait.Milliseconds(),
+			MaxWaitMs:   rec.MaxWait.Milliseconds(),
+			LastSeen:    rec.LastSeen.UnixNano(),
 		})
 	}
 
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch sortBy {
+		case "count":
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+		case "recent":
+			if a.LastSeen != b.LastSeen {
+				return a.LastSeen > b.LastSeen
+			}
+		default: // "wait"
+			if a.TotalWaitMs != b.TotalWaitMs {
+				return a.TotalWaitMs > b.TotalWaitMs
+			}
+		}
+		return a.ID < b.ID
+	})
+
+	if topN > 0 && topN < len(entries) {
+		entries = entries[:topN]
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(entries); err != nil {
 		http.Error(w, "failed to encode JSON", http.StatusInternalServerError)
 	}
 }
 
+// handleEvents streams persisted lock events from the event log as
+// newline-delimited JSON.
+//
+// Query parameters:
+//
+//	since=<unixnano>  only return events strictly after this timestamp.
+//	category=<cat>    restricts the result to that category.
+//	limit=N           stops after N entries.
+//
+// Responds with 400 Bad Request if since or limit is present but invalid,
+// or 404 Not Found if no event log is configured.
+func (s *HTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var since time.Time
+	if v := q.Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = time.Unix(0, n)
+	}
+
+	var limit int
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if err := s.monitor.StreamEvents(w, since, q.Get("category"), limit); err != nil {
+		http.Error(w, "event log not configured", http.StatusNotFound)
+	}
+}
+
 // handleStack returns the raw stack trace text for a given stack ID.
 //
 // The stack ID must be specified in the URL path as /stacks/{id}.
@@ -142,20 +325,29 @@ func (s *HTTPServer) handleStack(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte(stack))
 }
 
-// handleCategories returns JSON aggregated statistics by lock category.
+// handleCategories returns JSON aggregated statistics by lock category,
+// split into read and write stats.
 //
-// Each item contains the category name, total count of lock events,
-// average wait time (in milliseconds), and total wait time (in milliseconds).
+// Each item contains the category name plus, for each of read and write,
+// the count of lock events, average wait time (in milliseconds), and total
+// wait time (in milliseconds).
 func (s *HTTPServer) handleCategories(w http.ResponseWriter, r *http.Request) {
 	statsMap := s.monitor.GetCategoryStats()
 
 	var stats []CategoryStatsResponse
 	for cat, cs := range statsMap {
 		stats = append(stats, CategoryStatsResponse{
-			Category:    cat,
-			Count:       cs.Count,
-			AverageWait: float64(cs.AverageWait.Milliseconds()),
-			TotalWait:   cs.TotalWait.Milliseconds(),
+			Category: cat,
+			Read: TypeStatsResponse{
+				Count:       cs.Read.Count,
+				AverageWait: float64(cs.Read.AverageWait.Milliseconds()),
+				TotalWait:   cs.Read.TotalWait.Milliseconds(),
+			},
+			Write: TypeStatsResponse{
+				Count:       cs.Write.Count,
+				AverageWait: float64(cs.Write.AverageWait.Milliseconds()),
+				TotalWait:   cs.Write.TotalWait.Milliseconds(),
+			},
 		})
 	}
 
@@ -164,3 +356,64 @@ func (s *HTTPServer) handleCategories(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to encode JSON", http.StatusInternalServerError)
 	}
 }
+
+// handleHolders returns a JSON list of goroutines currently holding the
+// MonitoredRWMutex identified by the lock ID in the URL path /holders/{id}.
+//
+// Responds with 400 Bad Request if the ID is invalid.
+func (s *HTTPServer) handleHolders(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, s.endpoints.Holders)
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid lock id", http.StatusBadRequest)
+		return
+	}
+
+	holders := s.monitor.GetHolders(id)
+
+	entries := make([]HolderEntry, 0, len(holders))
+	for _, h := range holders {
+		entries = append(entries, HolderEntry{
+			GoroutineID: h.GoroutineID,
+			Type:        h.Type,
+			AcquiredAt:  h.AcquiredAt.UnixNano(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, "failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+// handleDeadlocks returns a JSON list of all currently suspected deadlocks.
+//
+// Each entry's stack_id and all_stacks_id (when non-zero) can be resolved
+// via the Stack endpoint to get the full stack text.
+func (s *HTTPServer) handleDeadlocks(w http.ResponseWriter, r *http.Request) {
+	deadlocksMap := s.monitor.GetDeadlocks()
+
+	entries := make([]DeadlockEntry, 0, len(deadlocksMap))
+	for id, info := range deadlocksMap {
+		var allStacksID uint64
+		if info.AllStacks != "" {
+			allStacksID = hashStack(info.AllStacks)
+		}
+		entries = append(entries, DeadlockEntry{
+			ID:                id,
+			Category:          info.Category,
+			Type:              info.Type,
+			WaitMs:            info.Wait.Milliseconds(),
+			Timestamp:         info.Timestamp.UnixNano(),
+			GoroutineID:       info.GoroutineID,
+			HolderGoroutineID: info.HolderGoroutineID,
+			StackID:           hashStack(info.Stack),
+			AllStacksID:       allStacksID,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, "failed to encode JSON", http.StatusInternalServerError)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	_ "bytes"
 	"github.com/cespare/xxhash/v2"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 func getStackTrace() string {
@@ -20,3 +22,41 @@ func getStackTrace() string {
 func hashStack(stack string) uint64 {
 	return xxhash.Sum64String(stack)
 }
+
+// currentGoroutineID returns the id of the calling goroutine. It is cheaper
+// than getStackTrace since it only needs the "goroutine N [state]:" header
+// line, not the full trace.
+func currentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	n := runtime.Stack(buf, false)
+	return parseGoroutineID(string(buf[:n]))
+}
+
+// allStacks dumps the stacks of every goroutine currently running, for
+// attaching to a suspected deadlock report.
+func allStacks() string {
+	for size := 1 << 16; size <= 1<<24; size *= 2 {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, true)
+		if n < size {
+			return string(buf[:n])
+		}
+	}
+	return "stack trace too deep"
+}
+
+// parseGoroutineID extracts the goroutine id from the first line of a stack
+// trace produced by getStackTrace, e.g. "goroutine 42 [running]:". Returns 0
+// if the id can't be parsed.
+func parseGoroutineID(stack string) uint64 {
+	line, _, _ := strings.Cut(stack, "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0
+	}
+	id, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}